@@ -0,0 +1,98 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package auth
+
+import (
+	"bytes"
+	"encoding/json"
+	"os/exec"
+
+	"github.com/cockroachdb/cockroach/util"
+)
+
+// CredentialHelperProvider is a CredentialProvider backed by a
+// docker-credential-<helper> binary on $PATH, speaking the same
+// JSON-over-stdio protocol as the Docker CLI's credential store: "get"
+// returns the stored credentials for a server URL, "store" saves them,
+// and "erase" removes them. This lets operators reuse existing helpers
+// like docker-credential-gcr, docker-credential-ecr-login or
+// docker-credential-acr-env without cockroach-prod knowing anything about
+// the cloud they authenticate against.
+type CredentialHelperProvider struct {
+	// Helper is the suffix after "docker-credential-", eg "gcr" to invoke
+	// "docker-credential-gcr".
+	Helper string
+}
+
+var _ CredentialProvider = CredentialHelperProvider{}
+
+// credentialHelperResponse is the JSON shape a helper's "get" command
+// returns on stdout.
+type credentialHelperResponse struct {
+	ServerURL string
+	Username  string
+	Secret    string
+}
+
+func (p CredentialHelperProvider) binary() string {
+	return "docker-credential-" + p.Helper
+}
+
+// Get implements CredentialProvider by running "docker-credential-<helper>
+// get" with serverURL on stdin.
+func (p CredentialHelperProvider) Get(serverURL string) (string, string, error) {
+	var out bytes.Buffer
+	cmd := exec.Command(p.binary(), "get")
+	cmd.Stdin = bytes.NewBufferString(serverURL)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", "", util.Errorf("%s get: %s", p.binary(), err)
+	}
+
+	var resp credentialHelperResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		return "", "", util.Errorf("%s returned invalid JSON: %s", p.binary(), err)
+	}
+	return resp.Username, resp.Secret, nil
+}
+
+// Store saves username/secret for serverURL via "docker-credential-<helper>
+// store".
+func (p CredentialHelperProvider) Store(serverURL, username, secret string) error {
+	req, err := json.Marshal(credentialHelperResponse{ServerURL: serverURL, Username: username, Secret: secret})
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(p.binary(), "store")
+	cmd.Stdin = bytes.NewReader(req)
+	if err := cmd.Run(); err != nil {
+		return util.Errorf("%s store: %s", p.binary(), err)
+	}
+	return nil
+}
+
+// Erase removes any stored credentials for serverURL via
+// "docker-credential-<helper> erase".
+func (p CredentialHelperProvider) Erase(serverURL string) error {
+	cmd := exec.Command(p.binary(), "erase")
+	cmd.Stdin = bytes.NewBufferString(serverURL)
+	if err := cmd.Run(); err != nil {
+		return util.Errorf("%s erase: %s", p.binary(), err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package auth
+
+import (
+	"encoding/gob"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// gobCredentials is what GobFileProvider persists to disk. Expiry and
+// RefreshToken are carried alongside Username/Secret so OAuth-backed
+// callers (see GetOAuthToken) can hand a caller's oauth2.Config enough to
+// silently refresh an expired access token, the way google.gobSource used
+// to before it was folded into this package.
+type gobCredentials struct {
+	Username     string
+	Secret       string
+	Expiry       time.Time
+	RefreshToken string
+}
+
+// GobFileProvider is a CredentialProvider backed by a single gob-encoded
+// file on disk, the same format google.gobSource has used since
+// cockroach-prod's first OAuth integration. serverURL is ignored: the file
+// holds exactly one set of credentials.
+type GobFileProvider string
+
+var _ CredentialProvider = GobFileProvider("")
+
+// Get implements CredentialProvider.
+func (f GobFileProvider) Get(serverURL string) (string, string, error) {
+	creds, err := f.load()
+	if err != nil {
+		return "", "", err
+	}
+	return creds.Username, creds.Secret, nil
+}
+
+// Put stores username/secret in the cache, creating the parent directory
+// if necessary.
+func (f GobFileProvider) Put(username, secret string) error {
+	return f.save(gobCredentials{Username: username, Secret: secret})
+}
+
+// OAuthToken is the subset of oauth2.Token GobFileProvider round-trips:
+// enough that a refresh-capable caller (eg oauth2.Config.Client) can mint
+// a fresh access token itself instead of the cache having to.
+type OAuthToken struct {
+	AccessToken  string
+	RefreshToken string
+	Expiry       time.Time
+}
+
+// GetOAuthToken returns the cached OAuth token, preserving Expiry and
+// RefreshToken. Plain Get would collapse those away, leaving a caller with
+// no way to tell the token is stale or to refresh it.
+func (f GobFileProvider) GetOAuthToken() (OAuthToken, error) {
+	creds, err := f.load()
+	if err != nil {
+		return OAuthToken{}, err
+	}
+	return OAuthToken{
+		AccessToken:  creds.Secret,
+		RefreshToken: creds.RefreshToken,
+		Expiry:       creds.Expiry,
+	}, nil
+}
+
+// PutOAuthToken stores tok in the cache under the "oauth2accesstoken"
+// username convention used for bearer tokens presented as a password.
+func (f GobFileProvider) PutOAuthToken(tok OAuthToken) error {
+	return f.save(gobCredentials{
+		Username:     "oauth2accesstoken",
+		Secret:       tok.AccessToken,
+		Expiry:       tok.Expiry,
+		RefreshToken: tok.RefreshToken,
+	})
+}
+
+func (f GobFileProvider) load() (gobCredentials, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return gobCredentials{}, err
+	}
+	defer file.Close()
+
+	var creds gobCredentials
+	if err := gob.NewDecoder(file).Decode(&creds); err != nil {
+		return gobCredentials{}, err
+	}
+	return creds, nil
+}
+
+func (f GobFileProvider) save(creds gobCredentials) error {
+	filename := string(f)
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	encErr := gob.NewEncoder(file).Encode(creds)
+	clErr := file.Close()
+	if encErr != nil {
+		return encErr
+	}
+	return clErr
+}
@@ -0,0 +1,210 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/cockroachdb/cockroach/util"
+)
+
+// EC2MetadataProvider is a CredentialProvider backed by the EC2 instance
+// metadata service, using IMDSv2 (a session token is required before
+// reading any metadata path) to fetch the temporary credentials for the
+// role attached to the running instance. serverURL is ignored.
+type EC2MetadataProvider struct {
+	// Role is the IAM instance profile role name. Left empty, the first
+	// role found under the security-credentials path is used.
+	Role string
+
+	client http.Client
+}
+
+var _ CredentialProvider = &EC2MetadataProvider{}
+var _ STSCredentialProvider = &EC2MetadataProvider{}
+
+const (
+	ec2MetadataBase       = "http://169.254.169.254/latest"
+	ec2TokenTTLHeader     = "X-aws-ec2-metadata-token-ttl-seconds"
+	ec2TokenHeader        = "X-aws-ec2-metadata-token"
+	ec2TokenTTLSeconds    = "21600"
+	ec2RoleCredentialsFmt = ec2MetadataBase + "/meta-data/iam/security-credentials/%s"
+)
+
+type ec2RoleCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	Token           string
+}
+
+// Get implements CredentialProvider. It always fails: IAM instance profile
+// credentials are temporary STS credentials that require the session token
+// returned alongside them, which Get's two-value signature has no way to
+// carry. Callers should use GetSTSCredentials instead; AWS rejects calls
+// made with the access key and secret alone.
+func (p *EC2MetadataProvider) Get(serverURL string) (string, string, error) {
+	return "", "", util.Errorf("EC2MetadataProvider credentials require a session token; call GetSTSCredentials instead of Get")
+}
+
+// GetSTSCredentials implements STSCredentialProvider, returning the
+// instance role's temporary credentials including the session token AWS
+// requires alongside them.
+func (p *EC2MetadataProvider) GetSTSCredentials(serverURL string) (STSCredentials, error) {
+	token, err := p.sessionToken()
+	if err != nil {
+		return STSCredentials{}, err
+	}
+
+	role := p.Role
+	if role == "" {
+		role, err = p.defaultRole(token)
+		if err != nil {
+			return STSCredentials{}, err
+		}
+	}
+
+	var creds ec2RoleCredentials
+	if err := p.getJSON(fmt.Sprintf(ec2RoleCredentialsFmt, role), token, &creds); err != nil {
+		return STSCredentials{}, err
+	}
+	return STSCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.Token,
+	}, nil
+}
+
+// sessionToken requests an IMDSv2 session token, valid for
+// ec2TokenTTLSeconds, that must accompany every subsequent metadata
+// request.
+func (p *EC2MetadataProvider) sessionToken() (string, error) {
+	req, err := http.NewRequest("PUT", ec2MetadataBase+"/api/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(ec2TokenTTLHeader, ec2TokenTTLSeconds)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", util.Errorf("fetching IMDSv2 token: status %s", resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+func (p *EC2MetadataProvider) defaultRole(token string) (string, error) {
+	req, err := http.NewRequest("GET", ec2MetadataBase+"/meta-data/iam/security-credentials/", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set(ec2TokenHeader, token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK || len(body) == 0 {
+		return "", util.Errorf("no IAM instance profile attached to this instance")
+	}
+	return string(body), nil
+}
+
+func (p *EC2MetadataProvider) getJSON(url, token string, v interface{}) error {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(ec2TokenHeader, token)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return util.Errorf("fetching %s: status %s", url, resp.Status)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// GCEMetadataProvider is a CredentialProvider backed by the GCE instance
+// metadata server, fetching the OAuth2 access token for the service
+// account attached to the running instance. serverURL is ignored; the
+// returned username is always "oauth2accesstoken", matching the
+// convention docker login and GCR's credential helper use for bearer
+// tokens presented as a password.
+type GCEMetadataProvider struct {
+	// ServiceAccount is the account alias to query, eg "default".
+	ServiceAccount string
+
+	client http.Client
+}
+
+var _ CredentialProvider = &GCEMetadataProvider{}
+
+const gceTokenUsername = "oauth2accesstoken"
+
+type gceTokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Get implements CredentialProvider.
+func (p *GCEMetadataProvider) Get(serverURL string) (string, string, error) {
+	account := p.ServiceAccount
+	if account == "" {
+		account = "default"
+	}
+	url := fmt.Sprintf("http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/%s/token", account)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", util.Errorf("fetching GCE metadata token: status %s", resp.Status)
+	}
+
+	var tok gceTokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return "", "", err
+	}
+	return gceTokenUsername, tok.AccessToken, nil
+}
@@ -0,0 +1,53 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+// Package auth defines a pluggable source of credentials (an OAuth
+// access token, an AWS access key pair, ...) so that drivers aren't tied
+// to one specific way of obtaining and caching them. A CredentialProvider
+// can be backed by a gob-encoded file on disk, a docker-credential-<name>
+// helper binary, or a cloud's instance metadata service.
+package auth
+
+// CredentialProvider resolves the username and secret to use against
+// serverURL, however it chooses to obtain and cache them.
+type CredentialProvider interface {
+	// Get returns the username/secret pair for serverURL. serverURL is
+	// provider-specific: a registry or API hostname for the credential
+	// helper and gob-file providers, ignored by the metadata providers
+	// since they always describe the instance they run on.
+	Get(serverURL string) (username, secret string, err error)
+}
+
+// STSCredentials is a temporary AWS credential set: an access key/secret
+// pair plus the session token AWS requires alongside them. It's returned
+// by providers that mint STS-style credentials rather than long-lived IAM
+// user keys, where CredentialProvider's two-value Get can't carry the
+// session token.
+type STSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// STSCredentialProvider is implemented by CredentialProviders whose
+// credentials are temporary and therefore need a session token to be
+// usable, eg EC2MetadataProvider. Callers that need working AWS
+// credentials should check for this interface before falling back to
+// plain Get.
+type STSCredentialProvider interface {
+	GetSTSCredentials(serverURL string) (STSCredentials, error)
+}
@@ -19,9 +19,16 @@ package base
 
 // Base context defaults.
 const (
-	defaultCerts  = "certs"
-	defaultPort   = 8080
-	defaultRegion = "aws:us-east-1"
+	defaultCerts      = "certs"
+	defaultPort       = 8080
+	defaultDriver     = "aws"
+	defaultRegionName = "us-east-1"
+	// defaultRuntime must match runtime.DefaultName. It's duplicated as a
+	// plain string, rather than imported, so that this widely-imported
+	// package doesn't drag in the runtime package (and, through it,
+	// containerd's gRPC client and docker/exec machinery) just to name a
+	// default. runtime.New validates the string when it's actually used.
+	defaultRuntime = "docker"
 )
 
 // Context is the base context object.
@@ -30,8 +37,28 @@ type Context struct {
 	Certs string
 	// Port for cockroach nodes to listen on.
 	Port int64
-	// Region to run in.
-	Region string
+	// Regions the cluster spans, eg [{aws us-east-1} {gce us-central1}]
+	// for a "--region aws:us-east-1,gce:us-central1" cluster. A
+	// single-region cluster is just a slice of length one.
+	Regions []RegionSpec
+	// Runtime is the container engine used to run cockroach nodes, eg
+	// "docker" or "containerd". It's a plain string, not runtime.Name, so
+	// that this package doesn't have to import runtime; callers pass it to
+	// runtime.New, which validates it.
+	Runtime string
+
+	// RegistryURL is the private registry to pull the cockroach image
+	// from. Empty means the default (Docker Hub).
+	RegistryURL string
+	// RegistryUsername and RegistryPassword authenticate against
+	// RegistryURL using basic auth. Leave empty when using
+	// RegistryTokenFile instead.
+	RegistryUsername string
+	RegistryPassword string
+	// RegistryTokenFile points at a file holding a pre-fetched bearer
+	// token (or the credentials to mint one, see the registry package)
+	// for RegistryURL, as an alternative to a username/password pair.
+	RegistryTokenFile string
 }
 
 // NewContext returns a context with initialized values.
@@ -45,5 +72,6 @@ func NewContext() *Context {
 func (ctx *Context) InitDefaults() {
 	ctx.Certs = defaultCerts
 	ctx.Port = defaultPort
-	ctx.Region = defaultRegion
+	ctx.Regions = []RegionSpec{{Driver: defaultDriver, Region: defaultRegionName}}
+	ctx.Runtime = defaultRuntime
 }
@@ -0,0 +1,67 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package base
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/util"
+)
+
+// RegionSpec identifies one region of a (possibly multi-region,
+// multi-cloud) cluster: which driver provisions it, and the
+// driver-specific region name within that cloud.
+type RegionSpec struct {
+	// Driver is the cloud driver to provision this region with, eg "aws",
+	// "gce" or "azure".
+	Driver string
+	// Region is the driver-specific region name, eg "us-east-1".
+	Region string
+}
+
+// String returns the "driver:region" form accepted by ParseRegions.
+func (r RegionSpec) String() string {
+	return r.Driver + ":" + r.Region
+}
+
+// Locality returns the cockroach "--locality" flag value for a node
+// started in this region, optionally narrowed to a specific zone.
+func (r RegionSpec) Locality(zone string) string {
+	locality := fmt.Sprintf("cloud=%s,region=%s", r.Driver, r.Region)
+	if zone != "" {
+		locality += ",zone=" + zone
+	}
+	return locality
+}
+
+// ParseRegions parses a "--region" flag value of the form
+// "aws:us-east-1,gce:us-central1" into one RegionSpec per comma-separated
+// entry.
+func ParseRegions(flag string) ([]RegionSpec, error) {
+	parts := strings.Split(flag, ",")
+	regions := make([]RegionSpec, 0, len(parts))
+	for _, part := range parts {
+		fields := strings.SplitN(part, ":", 2)
+		if len(fields) != 2 || fields[0] == "" || fields[1] == "" {
+			return nil, util.Errorf("invalid region %q, expected \"driver:region\"", part)
+		}
+		regions = append(regions, RegionSpec{Driver: fields[0], Region: fields[1]})
+	}
+	return regions, nil
+}
@@ -36,16 +36,26 @@ const (
 	dockerMachineVersionStringPrefix = "docker-machine version "
 	dockerMachineBinary              = "docker-machine"
 	dockerMachineStoragePath         = "${HOME}/.docker/machine"
-	cockroachNodeName                = `cockroach-%d`
+	cockroachNodeName                = `cockroach-%s-%d`
 )
 
 var (
-	cockroachNodeRegexp = regexp.MustCompile(`^cockroach-([0-9]+)$`)
+	cockroachNodeRegexp = regexp.MustCompile(`^cockroach-(.+)-([0-9]+)$`)
 )
 
-// MakeNodeName generates a cockroach node name for the given ID.
-func MakeNodeName(id int) string {
-	return fmt.Sprintf(cockroachNodeName, id)
+// MakeNodeName generates a cockroach node name for the given region and ID.
+// region is expected to be in "driver:region" form (eg "aws:us-east-1"),
+// tagging the machine with where it lives so ListCockroachNodesInRegion and
+// GetLargestNodeIndex can work on a single region's nodes at a time.
+func MakeNodeName(region string, id int) string {
+	return fmt.Sprintf(cockroachNodeName, sanitizeRegion(region), id)
+}
+
+// sanitizeRegion makes region safe to embed in a docker-machine name by
+// replacing the "driver:region" separator, which docker-machine names
+// can't contain.
+func sanitizeRegion(region string) string {
+	return strings.Replace(region, ":", "-", -1)
 }
 
 // CheckDockerMachine verifies that docker-machine is installed and
@@ -87,16 +97,36 @@ func ListCockroachNodes() ([]string, error) {
 	return ret, nil
 }
 
+// ListCockroachNodesInRegion returns the subset of ListCockroachNodes that
+// were created with MakeNodeName for the given region, giving a per-region
+// view of an otherwise flat pool of machines.
+func ListCockroachNodesInRegion(region string) ([]string, error) {
+	nodes, err := ListCockroachNodes()
+	if err != nil {
+		return nil, err
+	}
+	prefix := fmt.Sprintf("cockroach-%s-", sanitizeRegion(region))
+	ret := []string{}
+	for _, node := range nodes {
+		if strings.HasPrefix(node, prefix) {
+			ret = append(ret, node)
+		}
+	}
+	return ret, nil
+}
+
 // GetLargestNodeIndex takes a list of node names and returns the largest
 // node index seen. Returns 0 if no nodes are passed. Fails on parsing errors.
+// Pass the result of ListCockroachNodesInRegion to get the largest index
+// within a single region, rather than across the whole cluster.
 func GetLargestNodeIndex(nodes []string) (int, error) {
 	var largest int
 	for _, nodeName := range nodes {
 		match := cockroachNodeRegexp.FindStringSubmatch(nodeName)
-		if match == nil || len(match) != 2 {
+		if match == nil || len(match) != 3 {
 			return -1, util.Errorf("invalid cockroach node name: %s", nodeName)
 		}
-		index, err := strconv.Atoi(match[1])
+		index, err := strconv.Atoi(match[2])
 		if err != nil {
 			return -1, util.Errorf("invalid cockroach node name: %s", nodeName)
 		}
@@ -17,16 +17,65 @@
 
 package amazon
 
-import "github.com/awslabs/aws-sdk-go/aws"
+import (
+	"github.com/awslabs/aws-sdk-go/aws"
 
-// LoadAWSCredentials loads the credentials using the AWS api. This automatically
-// loads from ENV, or from the .aws/credentials file.
-// Returns the key-id and secret-key.
-func LoadAWSCredentials() (string, string, error) {
+	"github.com/cockroachdb/cockroach-prod/auth"
+)
+
+// sdkCredentialsProvider adapts the AWS SDK's own default credential chain
+// (env vars, then ~/.aws/credentials) to auth.CredentialProvider, so it's
+// just one more provider rather than the only option.
+type sdkCredentialsProvider struct{}
+
+var _ auth.CredentialProvider = sdkCredentialsProvider{}
+
+// Get implements auth.CredentialProvider. serverURL is ignored: the AWS
+// SDK's chain isn't scoped to a particular endpoint.
+func (sdkCredentialsProvider) Get(serverURL string) (string, string, error) {
 	creds, err := aws.DefaultCreds().Credentials()
 	if err != nil {
 		return "", "", err
 	}
-
 	return creds.AccessKeyID, creds.SecretAccessKey, nil
 }
+
+// GetSTSCredentials implements auth.STSCredentialProvider. The SDK's
+// default chain resolves assumed-role and instance-profile credentials as
+// readily as long-lived IAM user keys, so it may hand back a session token
+// too; pass it through when present.
+func (sdkCredentialsProvider) GetSTSCredentials(serverURL string) (auth.STSCredentials, error) {
+	creds, err := aws.DefaultCreds().Credentials()
+	if err != nil {
+		return auth.STSCredentials{}, err
+	}
+	return auth.STSCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+	}, nil
+}
+
+// LoadAWSCredentials loads credentials via provider, returning the key-id,
+// secret-key and, for providers minting temporary credentials (eg
+// auth.EC2MetadataProvider), the session token required to use them.
+func LoadAWSCredentials(provider auth.CredentialProvider) (string, string, string, error) {
+	if sts, ok := provider.(auth.STSCredentialProvider); ok {
+		creds, err := sts.GetSTSCredentials("")
+		if err != nil {
+			return "", "", "", err
+		}
+		return creds.AccessKeyID, creds.SecretAccessKey, creds.SessionToken, nil
+	}
+	accessKeyID, secretAccessKey, err := provider.Get("")
+	return accessKeyID, secretAccessKey, "", err
+}
+
+// LoadDefaultAWSCredentials loads credentials the way cockroach-prod
+// always has: from the environment, or the ~/.aws/credentials file,
+// via the AWS SDK's own default chain. Callers wanting
+// auth.EC2MetadataProvider or a docker-credential-helper instead should
+// call LoadAWSCredentials directly with one.
+func LoadDefaultAWSCredentials() (string, string, string, error) {
+	return LoadAWSCredentials(sdkCredentialsProvider{})
+}
@@ -0,0 +1,154 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package azure
+
+import (
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Azure/go-autorest/autorest/adal"
+
+	"github.com/cockroachdb/cockroach/util/log"
+)
+
+// Azure AD authentication endpoints. Tenant is filled in by the caller
+// since it is account-specific, much like the GCE client ID is fixed but
+// the token it produces is per-user.
+const (
+	activeDirectoryEndpoint = "https://login.microsoftonline.com/"
+	managementEndpoint      = "https://management.azure.com/"
+)
+
+// gobTokenCache is a gob-encoded file-backed token cache, mirroring
+// google.gobSource: avoid re-authenticating on every invocation by caching
+// the last good token on disk.
+type gobTokenCache string
+
+// cachedToken is what we persist: enough of adal.Token to refresh it
+// without re-running the service-principal or device-code flow.
+type cachedToken struct {
+	AccessToken  string
+	RefreshToken string
+	ExpiresOn    time.Time
+}
+
+// Load returns the cached token, or an error if none is found.
+func (f gobTokenCache) Load() (*cachedToken, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return nil, err
+	}
+	tok := &cachedToken{}
+	if err = gob.NewDecoder(file).Decode(tok); err != nil {
+		return nil, err
+	}
+	return tok, file.Close()
+}
+
+// Save stores the given token in the cache.
+func (f gobTokenCache) Save(tok *cachedToken) error {
+	filename := string(f)
+	parent := filepath.Dir(filename)
+	if err := os.MkdirAll(parent, 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	encErr := gob.NewEncoder(file).Encode(tok)
+	clErr := file.Close()
+	if encErr != nil {
+		return encErr
+	}
+	return clErr
+}
+
+// ServicePrincipalAuth authenticates using a service principal's client ID
+// and secret, caching the resulting token at authTokenPath so subsequent
+// runs don't need to re-authenticate against Azure AD.
+func ServicePrincipalAuth(tenantID, clientID, clientSecret, authTokenPath string) (string, error) {
+	cache := gobTokenCache(authTokenPath)
+	if tok, err := cache.Load(); err == nil && time.Now().Before(tok.ExpiresOn) {
+		return tok.AccessToken, nil
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+	if err != nil {
+		return "", err
+	}
+	spt, err := adal.NewServicePrincipalToken(*oauthConfig, clientID, clientSecret, managementEndpoint)
+	if err != nil {
+		return "", err
+	}
+	if err := spt.Refresh(); err != nil {
+		return "", err
+	}
+
+	token := spt.Token
+	if err := cache.Save(&cachedToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresOn:    time.Now().Add(time.Hour),
+	}); err != nil {
+		log.Infof("failed to cache azure token: %s", err)
+	}
+	return token.AccessToken, nil
+}
+
+// DeviceCodeAuth authenticates a user via the Azure AD device-code flow:
+// it prints a URL and short code for the user to visit in a browser, then
+// polls until they've completed the sign-in. The resulting token is cached
+// at authTokenPath the same way ServicePrincipalAuth caches its token.
+func DeviceCodeAuth(tenantID, clientID, authTokenPath string) (string, error) {
+	cache := gobTokenCache(authTokenPath)
+	if tok, err := cache.Load(); err == nil && time.Now().Before(tok.ExpiresOn) {
+		return tok.AccessToken, nil
+	}
+
+	oauthConfig, err := adal.NewOAuthConfig(activeDirectoryEndpoint, tenantID)
+	if err != nil {
+		return "", err
+	}
+	sender := &http.Client{}
+	deviceCode, err := adal.InitiateDeviceAuth(sender, *oauthConfig, clientID, managementEndpoint)
+	if err != nil {
+		return "", err
+	}
+	log.Infof("To authenticate, please go to %s", *deviceCode.VerificationURL)
+	fmt.Println(*deviceCode.Message)
+
+	token, err := adal.WaitForUserCompletion(sender, deviceCode)
+	if err != nil {
+		return "", err
+	}
+
+	if err := cache.Save(&cachedToken{
+		AccessToken:  token.AccessToken,
+		RefreshToken: token.RefreshToken,
+		ExpiresOn:    time.Now().Add(time.Hour),
+	}); err != nil {
+		log.Infof("failed to cache azure token: %s", err)
+	}
+	return token.AccessToken, nil
+}
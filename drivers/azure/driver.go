@@ -0,0 +1,101 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+// Package azure implements the drivers.Driver interface on top of
+// docker-machine's azure driver, the same way drivers/amazon and
+// drivers/google wrap the amazonec2 and google drivers.
+package azure
+
+import (
+	"fmt"
+
+	"github.com/cockroachdb/cockroach-prod/drivers"
+)
+
+const (
+	dockerMachineDriverName = "azure"
+	defaultSize             = "Standard_A2"
+	defaultImage            = "canonical:UbuntuServer:16.04.0-LTS:latest"
+)
+
+var _ drivers.Driver = Driver{}
+
+// Driver holds the Azure-specific parameters needed to create a
+// docker-machine backed node via "docker-machine create --driver azure".
+type Driver struct {
+	// SubscriptionID is the Azure subscription to create machines in.
+	SubscriptionID string
+	// ClientID is the service principal's application (client) ID. Left
+	// empty to fall back to the interactive device-code flow.
+	ClientID string
+	// ClientSecret is the service principal's secret. Ignored when ClientID
+	// is empty.
+	ClientSecret string
+	// TenantID is the Azure AD tenant the service principal belongs to.
+	TenantID string
+	// Location is the Azure region to create the machine in, eg "eastus".
+	Location string
+	// ResourceGroup is the resource group the machine and its NSG live in.
+	ResourceGroup string
+	// Size is the VM size, eg "Standard_A2". Defaults to defaultSize.
+	Size string
+	// Image is the VM image to boot. Defaults to defaultImage.
+	Image string
+}
+
+// DockerMachineDriver returns "azure", the docker-machine driver name.
+func (d Driver) DockerMachineDriver() string {
+	return dockerMachineDriverName
+}
+
+// DockerMachineCreateArgs returns the "--azure-*" flags docker-machine
+// needs to create a machine with this driver's configuration.
+func (d Driver) DockerMachineCreateArgs() []string {
+	size := d.Size
+	if size == "" {
+		size = defaultSize
+	}
+	image := d.Image
+	if image == "" {
+		image = defaultImage
+	}
+
+	args := []string{
+		"--azure-subscription-id", d.SubscriptionID,
+		"--azure-location", d.Location,
+		"--azure-resource-group", d.ResourceGroup,
+		"--azure-size", size,
+		"--azure-image", image,
+	}
+
+	if d.ClientID != "" {
+		args = append(args,
+			"--azure-client-id", d.ClientID,
+			"--azure-client-secret", d.ClientSecret,
+			"--azure-tenant-id", d.TenantID,
+		)
+	} else {
+		args = append(args, "--azure-use-device-code")
+	}
+
+	return args
+}
+
+// String implements fmt.Stringer for logging purposes.
+func (d Driver) String() string {
+	return fmt.Sprintf("azure driver (subscription=%s, location=%s)", d.SubscriptionID, d.Location)
+}
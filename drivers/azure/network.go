@@ -0,0 +1,77 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package azure
+
+import (
+	"fmt"
+
+	"github.com/Azure/azure-sdk-for-go/arm/network"
+	"github.com/Azure/go-autorest/autorest/to"
+
+	"github.com/cockroachdb/cockroach/util"
+)
+
+const (
+	securityGroupName       = "docker-machine"
+	cockroachRuleNamePrefix = "cockroach-port-"
+	allIPAddresses          = "*"
+	cockroachProtocol       = network.TCP
+)
+
+// FindSecurityGroup looks for the network security group created by
+// docker-machine's azure driver. We need it to add extra ingress rules,
+// just like amazon.FindSecurityGroup does for EC2 security groups.
+// Not finding the group is an error.
+func FindSecurityGroup(client network.SecurityGroupsClient, resourceGroup string) (network.SecurityGroup, error) {
+	nsg, err := client.Get(resourceGroup, securityGroupName, "")
+	if err != nil {
+		return network.SecurityGroup{}, util.Errorf("security group %q not found in resource group %q: %s",
+			securityGroupName, resourceGroup, err)
+	}
+	return nsg, nil
+}
+
+// AddCockroachSecurityGroupIngress adds an NSG rule opening cockroachPort
+// for inbound traffic, the Azure equivalent of
+// amazon.AddCockroachSecurityGroupIngress. Rule priorities in Azure NSGs
+// must be unique, so we pick one deterministically from the port.
+func AddCockroachSecurityGroupIngress(client network.SecurityGroupsClient, resourceGroup string, cockroachPort int32) error {
+	ruleName := fmt.Sprintf("%s%d", cockroachRuleNamePrefix, cockroachPort)
+	rule := network.SecurityRule{
+		Name: to.StringPtr(ruleName),
+		Properties: &network.SecurityRulePropertiesFormat{
+			Protocol:                 cockroachProtocol,
+			SourceAddressPrefix:      to.StringPtr(allIPAddresses),
+			SourcePortRange:          to.StringPtr(allIPAddresses),
+			DestinationAddressPrefix: to.StringPtr(allIPAddresses),
+			DestinationPortRange:     to.StringPtr(fmt.Sprintf("%d", cockroachPort)),
+			Access:                   network.Allow,
+			Direction:                network.Inbound,
+			Priority:                 to.Int32Ptr(cockroachRulePriority(cockroachPort)),
+		},
+	}
+
+	_, err := client.CreateOrUpdateSecurityRule(resourceGroup, securityGroupName, ruleName, rule, nil)
+	return err
+}
+
+// cockroachRulePriority derives an NSG rule priority (100-4096) from the
+// cockroach port so repeated calls for the same port are idempotent.
+func cockroachRulePriority(cockroachPort int32) int32 {
+	return 1000 + (cockroachPort % 3000)
+}
@@ -0,0 +1,34 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+// Package drivers defines the common interface implemented by each
+// supported cloud backend (amazon, google, azure, ...) so that the
+// docker package can create and manage machines without caring which
+// cloud they land on.
+package drivers
+
+// Driver is implemented by each cloud-specific driver package and wraps
+// just enough of docker-machine's own driver surface for cockroach-prod
+// to invoke "docker-machine create".
+type Driver interface {
+	// DockerMachineDriver returns the name docker-machine expects for the
+	// "--driver" flag, eg "amazonec2", "google" or "azure".
+	DockerMachineDriver() string
+	// DockerMachineCreateArgs returns the driver-specific flags to pass to
+	// "docker-machine create" in addition to "--driver" and the machine name.
+	DockerMachineCreateArgs() []string
+}
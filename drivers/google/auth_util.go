@@ -38,12 +38,9 @@
 package google
 
 import (
-	"encoding/gob"
 	"fmt"
 	"net/http"
-	"os"
 	"os/exec"
-	"path/filepath"
 	"strings"
 	"time"
 
@@ -52,6 +49,7 @@ import (
 	"golang.org/x/net/context"
 	"golang.org/x/oauth2"
 
+	"github.com/cockroachdb/cockroach-prod/auth"
 	"github.com/cockroachdb/cockroach/util/log"
 )
 
@@ -84,15 +82,47 @@ var oauth2Config = &oauth2.Config{
 }
 
 var _ oauth2.TokenSource = browserSource{}
-var _ oauth2.TokenSource = gobSource("")
 
-// browserSource is a token source that punts to a browser for oauth.
+// providerTokenSource adapts an auth.CredentialProvider to an
+// oauth2.TokenSource, treating its secret as a bearer access token. This
+// is the seam that lets newOauthClient accept any CredentialProvider --
+// the original gob-encoded file (auth.GobFileProvider), a
+// docker-credential-<name> helper, or a metadata-server provider -- in
+// place of the single hard-coded cache format it used to have.
+type providerTokenSource struct {
+	provider auth.CredentialProvider
+}
+
+func (s providerTokenSource) Token() (*oauth2.Token, error) {
+	_, token, err := s.provider.Get(tokenURL)
+	if err != nil {
+		return nil, err
+	}
+	return &oauth2.Token{AccessToken: token}, nil
+}
+
+// browserSource is a token source that first consults the wrapped
+// provider and, failing that, punts to a browser for oauth. If the
+// provider is an auth.GobFileProvider, it round-trips the full
+// oauth2.Token -- including Expiry and RefreshToken -- rather than just
+// the access token, so oauth2Config.Client can keep refreshing it lazily
+// long after the cached access token itself has expired. The token
+// obtained from the browser flow is cached back the same way so future
+// calls skip the browser entirely.
 type browserSource struct {
-	base oauth2.TokenSource
+	base auth.CredentialProvider
 }
 
 func (source browserSource) Token() (*oauth2.Token, error) {
-	if token, err := source.base.Token(); err == nil {
+	if cache, ok := source.base.(auth.GobFileProvider); ok {
+		if tok, err := cache.GetOAuthToken(); err == nil {
+			return &oauth2.Token{
+				AccessToken:  tok.AccessToken,
+				RefreshToken: tok.RefreshToken,
+				Expiry:       tok.Expiry,
+			}, nil
+		}
+	} else if token, err := (providerTokenSource{source.base}).Token(); err == nil {
 		return token, nil
 	}
 
@@ -103,56 +133,28 @@ func (source browserSource) Token() (*oauth2.Token, error) {
 	log.Infof("If the URL doesn't open please open it manually and copy the code here.")
 	openURL(authURL)
 	code := getCodeFromStdin()
-	return oauth2Config.Exchange(context.Background(), code)
-}
-
-// gobSource is a gob-encoding file-backed token source.
-type gobSource string
-
-// Token returns the cached token value, or an error if none is found.
-func (f gobSource) Token() (*oauth2.Token, error) {
-	file, err := os.Open(string(f))
+	token, err := oauth2Config.Exchange(context.Background(), code)
 	if err != nil {
 		return nil, err
 	}
-	tok := &oauth2.Token{}
-	if err = gob.NewDecoder(file).Decode(tok); err != nil {
-		return nil, err
-	}
-	return tok, file.Close()
-}
-
-// PutToken stores the given token in the cache.
-// TODO(marc): we should write to a tmp file and rename in case we error out.
-func (f gobSource) PutToken(tok *oauth2.Token) error {
-	filename := string(f)
-	// Create the parent directory if necessary.
-	parent := filepath.Dir(filename)
-	err := os.MkdirAll(parent, 0700)
-	if err != nil {
-		return err
-	}
-
-	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
-	if err != nil {
-		return err
-	}
-	encErr := gob.NewEncoder(file).Encode(tok)
-	clErr := file.Close()
 
-	if encErr != nil {
-		return encErr
-	}
-
-	if clErr != nil {
-		return clErr
+	if cache, ok := source.base.(auth.GobFileProvider); ok {
+		if err := cache.PutOAuthToken(auth.OAuthToken{
+			AccessToken:  token.AccessToken,
+			RefreshToken: token.RefreshToken,
+			Expiry:       token.Expiry,
+		}); err != nil {
+			log.Infof("failed to cache token: %s", err)
+		}
 	}
-
-	return nil
+	return token, nil
 }
 
-func newOauthClient(authTokenPath string) (*http.Client, error) {
-	token, err := oauth2.ReuseTokenSource(nil, browserSource{base: gobSource(authTokenPath)}).Token()
+// newOauthClient builds an *http.Client authenticated via provider,
+// falling back to the interactive browser flow when provider has no
+// cached credentials yet.
+func newOauthClient(provider auth.CredentialProvider) (*http.Client, error) {
+	token, err := (browserSource{base: provider}).Token()
 	if err != nil {
 		log.Infof("problem exchanging code: %s", err)
 		return nil, err
@@ -160,6 +162,14 @@ func newOauthClient(authTokenPath string) (*http.Client, error) {
 	return oauth2Config.Client(context.Background(), token), nil
 }
 
+// NewOauthClient builds an *http.Client the way cockroach-prod always
+// has: caching the token in a gob-encoded file at authTokenPath. Callers
+// wanting a docker-credential-helper or instance-metadata provider instead
+// should call newOauthClient directly with one.
+func NewOauthClient(authTokenPath string) (*http.Client, error) {
+	return newOauthClient(auth.GobFileProvider(authTokenPath))
+}
+
 func getCodeFromStdin() string {
 	fmt.Print("Enter code: ")
 	var code string
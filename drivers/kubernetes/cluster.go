@@ -0,0 +1,155 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package kubernetes
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/cockroachdb/cockroach/util"
+	"github.com/cockroachdb/cockroach/util/log"
+)
+
+// EnsureCluster creates the Service, PodDisruptionBudget and StatefulSet
+// for a cockroach cluster of the given size in namespace if they don't
+// already exist. It is safe to call repeatedly; use ScaleCluster to change
+// the node count afterwards.
+func EnsureCluster(clientset *kubernetes.Clientset, namespace string, replicas int32, port int64, image string) error {
+	svcClient := clientset.CoreV1().Services(namespace)
+	if _, err := svcClient.Get(serviceName, metav1.GetOptions{}); err != nil {
+		if _, err := svcClient.Create(cockroachService(namespace, port)); err != nil {
+			return err
+		}
+	}
+
+	pdbClient := clientset.PolicyV1beta1().PodDisruptionBudgets(namespace)
+	if _, err := pdbClient.Get(pdbName, metav1.GetOptions{}); err != nil {
+		if _, err := pdbClient.Create(cockroachPodDisruptionBudget(namespace)); err != nil {
+			return err
+		}
+	}
+
+	setClient := clientset.AppsV1().StatefulSets(namespace)
+	if _, err := setClient.Get(statefulSetName, metav1.GetOptions{}); err != nil {
+		_, err := setClient.Create(cockroachStatefulSet(namespace, replicas, port, image))
+		return err
+	}
+	return nil
+}
+
+// CreateMachine is the Kubernetes backend's equivalent of
+// docker.CreateMachine: rather than provisioning a new VM, it grows the
+// cockroach StatefulSet by one replica, which causes the StatefulSet
+// controller to schedule "cockroach-N" for the next ordinal N.
+func CreateMachine(clientset *kubernetes.Clientset, namespace string) error {
+	setClient := clientset.AppsV1().StatefulSets(namespace)
+	set, err := setClient.Get(statefulSetName, metav1.GetOptions{})
+	if err != nil {
+		return util.Errorf("cockroach StatefulSet not found in namespace %q, call EnsureCluster first: %s", namespace, err)
+	}
+
+	replicas := *set.Spec.Replicas + 1
+	log.Infof("scaling cockroach StatefulSet in %q from %d to %d replicas", namespace, *set.Spec.Replicas, replicas)
+	set.Spec.Replicas = &replicas
+	_, err = setClient.Update(set)
+	return err
+}
+
+// StartMachine ensures the StatefulSet has enough replicas for "name"
+// (eg "cockroach-2") to exist and be running; it is a no-op if the pod's
+// ordinal is already within the current replica count.
+func StartMachine(clientset *kubernetes.Clientset, namespace, name string) error {
+	index, err := podOrdinal(name)
+	if err != nil {
+		return err
+	}
+
+	setClient := clientset.AppsV1().StatefulSets(namespace)
+	set, err := setClient.Get(statefulSetName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if *set.Spec.Replicas > int32(index) {
+		return nil
+	}
+
+	replicas := int32(index) + 1
+	log.Infof("starting %s: scaling cockroach StatefulSet in %q to %d replicas", name, namespace, replicas)
+	set.Spec.Replicas = &replicas
+	_, err = setClient.Update(set)
+	return err
+}
+
+// StopMachine is the Kubernetes backend's equivalent of
+// docker.StopMachine. Kubernetes StatefulSets only ever remove pods from
+// the highest ordinal down, so this only succeeds for the last node in
+// the set; scaling down an interior node isn't something cockroach-prod
+// supports here, since it isn't something Kubernetes supports either.
+func StopMachine(clientset *kubernetes.Clientset, namespace, name string) error {
+	index, err := podOrdinal(name)
+	if err != nil {
+		return err
+	}
+
+	setClient := clientset.AppsV1().StatefulSets(namespace)
+	set, err := setClient.Get(statefulSetName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if *set.Spec.Replicas != int32(index)+1 {
+		return util.Errorf("%s is not the last node in the StatefulSet (%d replicas); "+
+			"Kubernetes can only scale down from the end", name, *set.Spec.Replicas)
+	}
+
+	replicas := int32(index)
+	log.Infof("stopping %s: scaling cockroach StatefulSet in %q to %d replicas", name, namespace, replicas)
+	set.Spec.Replicas = &replicas
+	_, err = setClient.Update(set)
+	return err
+}
+
+// ListCockroachNodes returns the names of the pods owned by the cockroach
+// StatefulSet, the Kubernetes backend's equivalent of
+// docker.ListCockroachNodes.
+func ListCockroachNodes(clientset *kubernetes.Clientset, namespace string) ([]string, error) {
+	pods, err := clientset.CoreV1().Pods(namespace).List(metav1.ListOptions{
+		LabelSelector: fmt.Sprintf("%s=%s", appLabel, appLabelValue),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make([]string, 0, len(pods.Items))
+	for _, pod := range pods.Items {
+		ret = append(ret, pod.Name)
+	}
+	return ret, nil
+}
+
+// podOrdinal extracts the StatefulSet ordinal from a pod name of the form
+// "cockroach-N".
+func podOrdinal(name string) (int, error) {
+	var index int
+	prefix := statefulSetName + "-"
+	if _, err := fmt.Sscanf(name, prefix+"%d", &index); err != nil {
+		return 0, util.Errorf("invalid cockroach pod name %q: %s", name, err)
+	}
+	return index, nil
+}
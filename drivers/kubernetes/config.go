@@ -0,0 +1,60 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+// Package kubernetes implements a cockroach-prod backend that deploys
+// onto an existing Kubernetes cluster instead of provisioning VMs via
+// docker-machine. Node lifecycle is expressed in terms of a single
+// StatefulSet: CreateMachine/StartMachine scale it up, StopMachine scales
+// it down, and ListCockroachNodes lists the pods it owns.
+package kubernetes
+
+import (
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// LoadConfig returns the *rest.Config to talk to the target cluster,
+// resolved the same way the docker/kubernetes CLI plugins do: honor
+// KUBECONFIG if set, otherwise fall back to "~/.kube/config", and finally
+// to in-cluster config when neither exists (ie we're running as a pod).
+func LoadConfig() (*rest.Config, error) {
+	if kubeconfig := os.Getenv("KUBECONFIG"); kubeconfig != "" {
+		return clientcmd.BuildConfigFromFlags("", kubeconfig)
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		defaultPath := filepath.Join(home, ".kube", "config")
+		if _, err := os.Stat(defaultPath); err == nil {
+			return clientcmd.BuildConfigFromFlags("", defaultPath)
+		}
+	}
+
+	return rest.InClusterConfig()
+}
+
+// NewClientset builds a Kubernetes clientset using LoadConfig.
+func NewClientset() (*kubernetes.Clientset, error) {
+	config, err := LoadConfig()
+	if err != nil {
+		return nil, err
+	}
+	return kubernetes.NewForConfig(config)
+}
@@ -0,0 +1,161 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package kubernetes
+
+import (
+	"fmt"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	policyv1beta1 "k8s.io/api/policy/v1beta1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// statefulSetName, serviceName and pdbName are fixed: each cockroach-prod
+// Kubernetes backend manages exactly one cluster's worth of objects per
+// namespace.
+const (
+	statefulSetName   = "cockroach"
+	serviceName       = "cockroach"
+	pdbName           = "cockroach-budget"
+	appLabel          = "app"
+	appLabelValue     = "cockroach"
+	cockroachImage    = "cockroachdb/cockroach"
+	defaultVolumeSize = "100Gi"
+)
+
+// joinAddresses returns the "--join" targets for a replicas-node cluster:
+// every pod's stable DNS name under the headless Service, so each one can
+// find the others and form a single cluster instead of bootstrapping its
+// own.
+func joinAddresses(replicas int32) []string {
+	addrs := make([]string, 0, replicas)
+	for i := int32(0); i < replicas; i++ {
+		addrs = append(addrs, fmt.Sprintf("%s-%d.%s", statefulSetName, i, serviceName))
+	}
+	return addrs
+}
+
+// podLabels is applied to every pod the StatefulSet creates and used as
+// the label selector for the headless Service, the PodDisruptionBudget,
+// and ListCockroachNodes.
+func podLabels() map[string]string {
+	return map[string]string{appLabel: appLabelValue}
+}
+
+// cockroachStatefulSet builds the StatefulSet that runs "replicas"
+// cockroach nodes, each listening on port and storing its data on a
+// per-pod PersistentVolumeClaim.
+func cockroachStatefulSet(namespace string, replicas int32, port int64, image string) *appsv1.StatefulSet {
+	if image == "" {
+		image = cockroachImage
+	}
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      statefulSetName,
+			Namespace: namespace,
+			Labels:    podLabels(),
+		},
+		Spec: appsv1.StatefulSetSpec{
+			ServiceName: serviceName,
+			Replicas:    &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: podLabels(),
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels: podLabels(),
+				},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{
+						{
+							Name:    appLabelValue,
+							Image:   image,
+							Command: []string{"/bin/sh", "-c"},
+							Args: []string{fmt.Sprintf(
+								"exec /cockroach/cockroach start --insecure --join=%s --advertise-host=$(hostname).%s",
+								strings.Join(joinAddresses(replicas), ","), serviceName,
+							)},
+							Ports: []corev1.ContainerPort{
+								{Name: "cockroach", ContainerPort: int32(port)},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{Name: "datadir", MountPath: "/cockroach/cockroach-data"},
+							},
+						},
+					},
+				},
+			},
+			VolumeClaimTemplates: []corev1.PersistentVolumeClaim{
+				{
+					ObjectMeta: metav1.ObjectMeta{Name: "datadir"},
+					Spec: corev1.PersistentVolumeClaimSpec{
+						AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+						Resources: corev1.ResourceRequirements{
+							Requests: corev1.ResourceList{
+								corev1.ResourceStorage: resource.MustParse(defaultVolumeSize),
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// cockroachService builds the headless Service StatefulSet pods register
+// under for stable DNS names ("cockroach-0.cockroach", ...).
+func cockroachService(namespace string, port int64) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName,
+			Namespace: namespace,
+			Labels:    podLabels(),
+		},
+		Spec: corev1.ServiceSpec{
+			ClusterIP: corev1.ClusterIPNone,
+			Selector:  podLabels(),
+			Ports: []corev1.ServicePort{
+				{Name: "cockroach", Port: int32(port), TargetPort: intstr.FromInt(int(port))},
+			},
+		},
+	}
+}
+
+// cockroachPodDisruptionBudget builds a PDB that keeps voluntary
+// disruptions (eg node drains) from taking down a majority of the
+// cluster's ranges at once.
+func cockroachPodDisruptionBudget(namespace string) *policyv1beta1.PodDisruptionBudget {
+	maxUnavailable := intstr.FromInt(1)
+	return &policyv1beta1.PodDisruptionBudget{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      pdbName,
+			Namespace: namespace,
+			Labels:    podLabels(),
+		},
+		Spec: policyv1beta1.PodDisruptionBudgetSpec{
+			MaxUnavailable: &maxUnavailable,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: podLabels(),
+			},
+		},
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+// Package registry implements the client side of the Docker Registry v2
+// token authentication spec (as used by Docker Hub, GCR, ACR, etc): given
+// a realm/service/scope, it exchanges credentials (typically a Keystone or
+// OIDC identity) for a short-lived bearer token accepted by the registry's
+// HTTP API.
+//
+// See https://docs.docker.com/registry/spec/auth/token/ for the spec this
+// implements: a GET to <realm>?service=<service>&scope=<scope> returns a
+// JSON body with a "token" (or "access_token") field, to be presented as
+// "Authorization: Bearer <token>" on subsequent registry requests.
+package registry
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/cockroachdb/cockroach/util"
+)
+
+// TokenSource exchanges credentials for registry bearer tokens and caches
+// the result until it is asked to refresh.
+type TokenSource struct {
+	// Realm is the token endpoint advertised by the registry's 401
+	// "WWW-Authenticate: Bearer realm=...,service=..." challenge.
+	Realm string
+	// Service identifies the registry to the token server.
+	Service string
+	// Username and Password authenticate the token request. Either may be
+	// empty for an anonymous pull, depending on the registry's policy.
+	Username string
+	Password string
+
+	client http.Client
+	cached string
+}
+
+// tokenResponse is the subset of the token spec's response body we need.
+// Some implementations return "token", others "access_token"; we accept
+// either.
+type tokenResponse struct {
+	Token       string `json:"token"`
+	AccessToken string `json:"access_token"`
+}
+
+// Token returns a bearer token authorizing the given scope (eg
+// "repository:cockroachdb/cockroach:pull"), fetching a fresh one from the
+// token server.
+func (s *TokenSource) Token(scope string) (string, error) {
+	req, err := http.NewRequest("GET", s.Realm, nil)
+	if err != nil {
+		return "", err
+	}
+	q := url.Values{}
+	q.Set("service", s.Service)
+	q.Set("scope", scope)
+	req.URL.RawQuery = q.Encode()
+	if s.Username != "" {
+		req.SetBasicAuth(s.Username, s.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", util.Errorf("token request to %s failed with status %s", s.Realm, resp.Status)
+	}
+
+	var tr tokenResponse
+	if err := json.NewDecoder(resp.Body).Decode(&tr); err != nil {
+		return "", err
+	}
+
+	token := tr.Token
+	if token == "" {
+		token = tr.AccessToken
+	}
+	if token == "" {
+		return "", util.Errorf("token response from %s contained neither token nor access_token", s.Realm)
+	}
+	s.cached = token
+	return token, nil
+}
+
+// Authorize performs req against the registry, retrying once with a fresh
+// token from Token if the registry responds 401 Unauthorized.
+func (s *TokenSource) Authorize(req *http.Request, scope string) (*http.Response, error) {
+	token := s.cached
+	if token == "" {
+		var err error
+		if token, err = s.Token(scope); err != nil {
+			return nil, err
+		}
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	token, err = s.Token(scope)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	return s.client.Do(req)
+}
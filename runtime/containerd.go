@@ -0,0 +1,153 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package runtime
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/containerd/containerd"
+	"github.com/containerd/containerd/cio"
+	"github.com/containerd/containerd/namespaces"
+	"github.com/containerd/containerd/remotes/docker"
+
+	"golang.org/x/net/context"
+
+	"github.com/cockroachdb/cockroach/util"
+	"github.com/cockroachdb/cockroach/util/log"
+)
+
+// containerdNamespace isolates cockroach-prod managed containers from
+// anything else running on the same containerd instance (eg Kubernetes'
+// own pods, which live in the "k8s.io" namespace).
+const containerdNamespace = "cockroach-prod"
+
+// containerdSocket is the default containerd gRPC socket, matching the
+// upstream containerd 1.2 default.
+const containerdSocket = "/run/containerd/containerd.sock"
+
+// containerdRuntime talks to containerd directly over its gRPC API,
+// bypassing docker-machine and the Docker daemon entirely. nodeName is
+// unused for now: containerd has no notion of remote hosts the way
+// "docker-machine config" does, so this assumes it is being run on or
+// tunneled to the target node.
+type containerdRuntime struct{}
+
+// Name implements Runtime.
+func (containerdRuntime) Name() Name {
+	return Containerd
+}
+
+func (containerdRuntime) client() (*containerd.Client, context.Context, error) {
+	client, err := containerd.New(containerdSocket)
+	if err != nil {
+		return nil, nil, util.Errorf("connecting to containerd at %s: %s", containerdSocket, err)
+	}
+	return client, namespaces.WithNamespace(context.Background(), containerdNamespace), nil
+}
+
+// PullCockroachImage implements Runtime. It resolves and pulls the image
+// through containerd's own resolver, unpacking it so it's ready to run. If
+// auth is non-zero, the resolver authenticates each request against the
+// registry with it instead of pulling anonymously.
+func (containerdRuntime) PullCockroachImage(nodeName, image string, auth Auth) error {
+	client, ctx, err := containerdRuntime{}.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	opts := []containerd.RemoteOpt{containerd.WithPullUnpack}
+	if !auth.empty() {
+		opts = append(opts, containerd.WithResolver(docker.NewResolver(docker.ResolverOptions{
+			Credentials: func(string) (string, string, error) {
+				if auth.Token != "" {
+					return "", auth.Token, nil
+				}
+				return auth.Username, auth.Password, nil
+			},
+		})))
+	}
+
+	log.Infof("pulling %s via containerd", image)
+	_, err = client.Pull(ctx, image, opts...)
+	return err
+}
+
+// RunCockroach implements Runtime. It creates a container and task from
+// the already-pulled image and starts it, returning the task ID.
+func (containerdRuntime) RunCockroach(nodeName, image string, args []string) (string, error) {
+	client, ctx, err := containerdRuntime{}.client()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+
+	img, err := client.GetImage(ctx, image)
+	if err != nil {
+		return "", util.Errorf("image %s not found, call PullCockroachImage first: %s", image, err)
+	}
+
+	id := fmt.Sprintf("cockroach-%s", nodeName)
+	container, err := client.NewContainer(ctx, id,
+		containerd.WithNewSnapshot(id+"-rootfs", img),
+		containerd.WithNewSpec(containerd.WithImageConfig(img), containerd.WithProcessArgs(args...)),
+	)
+	if err != nil {
+		return "", err
+	}
+
+	task, err := container.NewTask(ctx, cio.NewCreator(cio.WithStdio))
+	if err != nil {
+		return "", err
+	}
+	if err := task.Start(ctx); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// StreamLogs implements Runtime. containerd has no built-in log store the
+// way the Docker daemon does, so we attach to the task's io streams and
+// copy them to our own stdout/stderr until it exits.
+func (containerdRuntime) StreamLogs(nodeName, containerID string) error {
+	client, ctx, err := containerdRuntime{}.client()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	container, err := client.LoadContainer(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	task, err := container.Task(ctx, cio.NewAttach(cio.WithStreams(nil, os.Stdout, os.Stderr)))
+	if err != nil {
+		return err
+	}
+
+	statusC, err := task.Wait(ctx)
+	if err != nil {
+		return err
+	}
+	status := <-statusC
+	if status.ExitCode() != 0 {
+		return util.Errorf("cockroach container %s exited with status %d", containerID, status.ExitCode())
+	}
+	return nil
+}
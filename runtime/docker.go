@@ -0,0 +1,107 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+package runtime
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/cockroachdb/cockroach-prod/docker"
+	"github.com/cockroachdb/cockroach/util/log"
+)
+
+// dockerRuntime is the original Runtime implementation: it shells out to
+// the Docker CLI, pointed at the remote daemon via the flags docker-machine
+// hands back from "docker-machine config".
+type dockerRuntime struct{}
+
+// Name implements Runtime.
+func (dockerRuntime) Name() Name {
+	return Docker
+}
+
+// dockerTokenUsername is the username docker login expects when
+// authenticating with a bearer token instead of a username/password pair,
+// following the convention used by cloud registry credential helpers (eg
+// GCR's "oauth2accesstoken").
+const dockerTokenUsername = "oauth2accesstoken"
+
+// PullCockroachImage implements Runtime. If auth is non-zero, it runs
+// "docker login" against auth.RegistryURL first so the subsequent pull is
+// authorized.
+func (d dockerRuntime) PullCockroachImage(nodeName, image string, auth Auth) error {
+	if !auth.empty() {
+		if err := d.login(nodeName, auth); err != nil {
+			return err
+		}
+	}
+	return d.run(nodeName, "pull", image)
+}
+
+// login runs "docker login" against the node's remote daemon using either
+// auth's username/password or, if Token is set, the bearer token.
+func (dockerRuntime) login(nodeName string, auth Auth) error {
+	username, password := auth.Username, auth.Password
+	if auth.Token != "" {
+		username, password = dockerTokenUsername, auth.Token
+	}
+	return dockerRuntime{}.run(nodeName, "login", "-u", username, "-p", password, auth.RegistryURL)
+}
+
+// RunCockroach implements Runtime.
+func (dockerRuntime) RunCockroach(nodeName, image string, args []string) (string, error) {
+	runArgs := append([]string{"run", "-d", image}, args...)
+	return dockerRuntime{}.runOutput(nodeName, runArgs...)
+}
+
+// StreamLogs implements Runtime.
+func (dockerRuntime) StreamLogs(nodeName, containerID string) error {
+	return dockerRuntime{}.run(nodeName, "logs", "-f", containerID)
+}
+
+// run invokes "docker <args>" against nodeName's daemon, streaming
+// stdout/stderr directly to ours.
+func (dockerRuntime) run(nodeName string, args ...string) error {
+	flags, err := docker.GetDockerFlags(nodeName)
+	if err != nil {
+		return err
+	}
+	log.Infof("running: docker %s %s", strings.Join(flags, " "), strings.Join(args, " "))
+	cmd := exec.Command("docker", append(flags, args...)...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// runOutput is like run, but returns the trimmed stdout instead of
+// streaming it, for commands like "docker run" whose output is an ID we
+// need to hang onto.
+func (dockerRuntime) runOutput(nodeName string, args ...string) (string, error) {
+	flags, err := docker.GetDockerFlags(nodeName)
+	if err != nil {
+		return "", err
+	}
+	log.Infof("running: docker %s %s", strings.Join(flags, " "), strings.Join(args, " "))
+	out, err := exec.Command("docker", append(flags, args...)...).Output()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
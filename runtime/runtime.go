@@ -0,0 +1,89 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+//
+// Author: Bram Gruneir (bram.gruneir@gmail.com)
+
+// Package runtime abstracts the container engine used to run the
+// cockroach binary on a provisioned node, so that the docker package's
+// docker-machine based implementation is no longer the only option.
+package runtime
+
+import "github.com/cockroachdb/cockroach/util"
+
+// Name identifies a supported container runtime backend.
+type Name string
+
+// Supported runtime backends, for the "--runtime" flag.
+const (
+	Docker     Name = "docker"
+	Containerd Name = "containerd"
+)
+
+// DefaultName is the runtime used when "--runtime" isn't specified, keeping
+// existing docker-machine based behavior as the default.
+const DefaultName = Docker
+
+// Auth holds the credentials needed to pull the cockroach image from a
+// private registry. The zero value means "no auth", ie the registry
+// allows anonymous pulls (or none was configured, for the Docker Hub
+// default).
+type Auth struct {
+	// RegistryURL is the registry to authenticate against. Empty means the
+	// default (Docker Hub).
+	RegistryURL string
+	// Username and Password authenticate via basic auth. Leave both empty
+	// when using Token instead.
+	Username string
+	Password string
+	// Token is a pre-fetched bearer token, as minted by registry.TokenSource.
+	Token string
+}
+
+// empty reports whether a contains no credentials at all, ie the pull
+// should proceed anonymously against the default registry.
+func (a Auth) empty() bool {
+	return a == Auth{}
+}
+
+// Runtime is implemented by each container engine backend. It operates on
+// a single already-provisioned node, identified by its docker-machine (or
+// equivalent) name.
+type Runtime interface {
+	// Name returns the runtime's name, as passed to "--runtime".
+	Name() Name
+	// PullCockroachImage pulls the given cockroachdb/cockroach image
+	// reference onto the node, authenticating with auth if it is
+	// non-zero, and returns once it is available to run.
+	PullCockroachImage(nodeName, image string, auth Auth) error
+	// RunCockroach starts the cockroach binary on the node with the given
+	// arguments and returns an identifier for the running container/task
+	// that can later be passed to StreamLogs.
+	RunCockroach(nodeName, image string, args []string) (string, error)
+	// StreamLogs streams the given container/task's logs to stdout until
+	// it exits or an error occurs.
+	StreamLogs(nodeName, containerID string) error
+}
+
+// New returns the Runtime implementation for the given name.
+func New(name Name) (Runtime, error) {
+	switch name {
+	case Docker:
+		return dockerRuntime{}, nil
+	case Containerd:
+		return containerdRuntime{}, nil
+	default:
+		return nil, util.Errorf("unknown runtime %q, expected one of %q or %q", name, Docker, Containerd)
+	}
+}